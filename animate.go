@@ -0,0 +1,282 @@
+package goavatar
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"io"
+	"math"
+)
+
+// AnimationMode selects how successive frames of an animated avatar
+// evolve from one another.
+type AnimationMode int
+
+const (
+	// HashChainMode re-hashes the seed string each frame with
+	// generateHash (MD5, hex-encoded), so every frame is a fresh,
+	// still-deterministic pattern derived from the last.
+	HashChainMode AnimationMode = iota
+	// LayerFadeMode keeps the pattern fixed and fades each layer in,
+	// then out, over the animation by ramping its alpha.
+	LayerFadeMode
+	// HueRotateMode keeps the pattern fixed and rotates the hue of
+	// every layer color a little further each frame.
+	HueRotateMode
+)
+
+// animOptions holds the animation-only configuration layered on top of
+// the regular avatar options.
+type animOptions struct {
+	frames     int
+	frameDelay int // in 1/100ths of a second, per image/gif convention
+	mode       AnimationMode
+}
+
+// defaultAnimOptions mirrors defaultOptions: sane values that Just Work.
+func defaultAnimOptions() animOptions {
+	return animOptions{
+		frames:     8,
+		frameDelay: 10, // 100ms
+		mode:       HashChainMode,
+	}
+}
+
+// WithFrames sets how many frames MakeAnimated renders (minimum 2).
+func WithFrames(n int) OptFunc {
+	return func(o *options) {
+		if n >= 2 {
+			o.animation.frames = n
+		}
+	}
+}
+
+// WithFrameDelay sets the per-frame delay in milliseconds.
+func WithFrameDelay(ms int) OptFunc {
+	return func(o *options) {
+		if ms <= 0 {
+			return
+		}
+		// image/gif counts delay in 100ths of a second; round to the
+		// nearest tick and never let a small-but-positive ms round down
+		// to 0, which image/gif (and most viewers) treat as "as fast as
+		// possible" instead of the short-but-real delay the caller asked for.
+		delay := (ms + 5) / 10
+		if delay < 1 {
+			delay = 1
+		}
+		o.animation.frameDelay = delay
+	}
+}
+
+// WithAnimationMode selects how frames evolve; see AnimationMode.
+func WithAnimationMode(mode AnimationMode) OptFunc {
+	return func(o *options) {
+		o.animation.mode = mode
+	}
+}
+
+// MakeAnimated produces a looping animated avatar: the seed (or its
+// colors) evolves every frame according to the selected AnimationMode,
+// and all frames share a single global palette built from the
+// background plus every color actually used across all frames, so
+// gif.EncodeAll produces small files.
+func MakeAnimated(input string, opts ...OptFunc) *gif.GIF {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	// Compute every frame's layers up front: LayerFadeMode and
+	// HueRotateMode vary the *color* of an otherwise-fixed pattern, so
+	// the palette needs each frame's actual color, not just the base
+	// layer colors computeLayers(input, o) would give on its own -
+	// otherwise paintPaletted's nearest-match lookup snaps every frame
+	// back to the same base color and the animation never visibly changes.
+	frames := make([][]layerGrid, o.animation.frames)
+	seed := input
+	for frame := 0; frame < o.animation.frames; frame++ {
+		switch o.animation.mode {
+		case LayerFadeMode:
+			frames[frame] = fadeLayers(computeLayers(input, o), o.bgColor, frame, o.animation.frames)
+		case HueRotateMode:
+			frames[frame] = hueRotateLayers(computeLayers(input, o), frame, o.animation.frames)
+		default: // HashChainMode
+			frames[frame] = computeLayers(seed, o)
+			seed = generateHash(seed)
+		}
+	}
+
+	pal := buildAnimationPalette(o, frames)
+
+	g := &gif.GIF{
+		Image:     make([]*image.Paletted, 0, o.animation.frames),
+		Delay:     make([]int, 0, o.animation.frames),
+		LoopCount: 0, // loop forever
+	}
+
+	for _, frameLayers := range frames {
+		img := paintPaletted(frameLayers, pal, o)
+		g.Image = append(g.Image, img)
+		g.Delay = append(g.Delay, o.animation.frameDelay)
+	}
+
+	return g
+}
+
+// EncodeGIF writes a previously built *gif.GIF to w, for convenience
+// alongside MakeAndEncode.
+func EncodeGIF(w io.Writer, g *gif.GIF) error {
+	return gif.EncodeAll(w, g)
+}
+
+// buildAnimationPalette collects the background color plus every color
+// that appears in any precomputed frame, so paintPaletted's nearest-
+// match lookup finds an exact entry instead of snapping to a base color.
+// GIF palettes cap out at 256 entries; if more distinct colors than that
+// are in play (an unusually high frame/layer count), the remainder falls
+// back to paintPaletted's nearest-match behavior.
+func buildAnimationPalette(o options, frames [][]layerGrid) color.Palette {
+	pal := color.Palette{o.bgColor}
+	seen := map[color.RGBA]bool{o.bgColor: true}
+
+	for _, layers := range frames {
+		for _, layer := range layers {
+			if len(pal) >= 256 {
+				return pal
+			}
+			if !seen[layer.color] {
+				seen[layer.color] = true
+				pal = append(pal, layer.color)
+			}
+		}
+	}
+
+	return pal
+}
+
+// paintPaletted renders a frame's layers into an *image.Paletted against
+// pal, snapping each layer color to its nearest palette entry so every
+// frame shares the same global palette.
+func paintPaletted(layers []layerGrid, pal color.Palette, o options) *image.Paletted {
+	img := image.NewPaletted(image.Rect(0, 0, o.size, o.size), pal)
+	draw.Draw(img, img.Bounds(), &image.Uniform{o.bgColor}, image.Point{}, draw.Src)
+
+	for _, layer := range layers {
+		idx := uint8(pal.Index(layer.color))
+		for y, row := range layer.on {
+			for x, on := range row {
+				if on {
+					drawPalettedPixel(img, x, y, idx, o.gridSize, o.size)
+				}
+			}
+		}
+	}
+
+	return img
+}
+
+// fadeLayers ramps each layer's alpha up then back down across the
+// animation and composites the result over bg, so layers visibly fade in
+// and out instead of the pattern changing. The compositing matters
+// because a GIF palette stores only RGB per entry, not alpha.
+func fadeLayers(layers []layerGrid, bg color.RGBA, frame, total int) []layerGrid {
+	// triangle wave 0..1..0 across the animation
+	half := float64(total) / 2
+	t := float64(frame)
+	var ramp float64
+	if t <= half {
+		ramp = t / half
+	} else {
+		ramp = (float64(total) - t) / half
+	}
+
+	out := make([]layerGrid, len(layers))
+	for i, l := range layers {
+		c := l.color
+		c.A = uint8(float64(c.A) * ramp)
+		out[i] = layerGrid{color: srcOverBlend(bg, c), on: l.on}
+	}
+	return out
+}
+
+// hueRotateLayers rotates every layer color's hue a fixed step further
+// each frame.
+func hueRotateLayers(layers []layerGrid, frame, total int) []layerGrid {
+	step := 360.0 / float64(total)
+	out := make([]layerGrid, len(layers))
+	for i, l := range layers {
+		out[i] = layerGrid{color: rotateHue(l.color, step*float64(frame)), on: l.on}
+	}
+	return out
+}
+
+// rotateHue rotates c's hue by degrees, preserving perceived saturation
+// and value via a simple HSV round-trip.
+func rotateHue(c color.RGBA, degrees float64) color.RGBA {
+	h, s, v := rgbToHSV(c.R, c.G, c.B)
+	h = h + degrees
+	for h >= 360 {
+		h -= 360
+	}
+	for h < 0 {
+		h += 360
+	}
+	r, g, b := hsvToRGB(h, s, v)
+	return color.RGBA{r, g, b, c.A}
+}
+
+// rgbToHSV converts 8-bit RGB to hue (degrees, 0-360), saturation and
+// value (both 0-1).
+func rgbToHSV(r, g, b uint8) (h, s, v float64) {
+	rf, gf, bf := float64(r)/255, float64(g)/255, float64(b)/255
+	max := math.Max(rf, math.Max(gf, bf))
+	min := math.Min(rf, math.Min(gf, bf))
+	v = max
+	delta := max - min
+
+	if delta == 0 {
+		return 0, 0, v
+	}
+	s = delta / max
+
+	switch max {
+	case rf:
+		h = 60 * ((gf - bf) / delta)
+	case gf:
+		h = 60 * (((bf-rf)/delta) + 2)
+	default:
+		h = 60 * (((rf-gf)/delta) + 4)
+	}
+	if h < 0 {
+		h += 360
+	}
+	return h, s, v
+}
+
+// hsvToRGB converts hue (degrees, 0-360), saturation and value (both
+// 0-1) back to 8-bit RGB.
+func hsvToRGB(h, s, v float64) (r, g, b uint8) {
+	c := v * s
+	x := c * (1 - math.Abs(math.Mod(h/60, 2)-1))
+	m := v - c
+
+	var rf, gf, bf float64
+	switch {
+	case h < 60:
+		rf, gf, bf = c, x, 0
+	case h < 120:
+		rf, gf, bf = x, c, 0
+	case h < 180:
+		rf, gf, bf = 0, c, x
+	case h < 240:
+		rf, gf, bf = 0, x, c
+	case h < 300:
+		rf, gf, bf = x, 0, c
+	default:
+		rf, gf, bf = c, 0, x
+	}
+
+	return uint8((rf + m) * 255), uint8((gf + m) * 255), uint8((bf + m) * 255)
+}
@@ -0,0 +1,97 @@
+package goavatar
+
+import (
+	"image/color"
+	"testing"
+)
+
+func TestBlendCellColorModes(t *testing.T) {
+	dst := color.RGBA{100, 100, 100, 255}
+	src := color.RGBA{200, 50, 10, 255}
+
+	tests := []struct {
+		name string
+		mode BlendMode
+		want color.RGBA
+	}{
+		{"SrcOver", SrcOver, src},
+		{"Multiply", Multiply, color.RGBA{78, 19, 3, 255}},
+		{"Screen", Screen, color.RGBA{222, 131, 107, 255}},
+		{"Additive", Additive, color.RGBA{255, 150, 110, 255}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := blendCellColor(dst, src, tt.mode)
+			if got != tt.want {
+				t.Errorf("blendCellColor(%v, %v, %v) = %v, want %v", dst, src, tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestBlendCellColorTranslucentSrc checks that a translucent src only
+// partially takes effect over dst, regardless of mode, instead of
+// overwriting dst outright.
+func TestBlendCellColorTranslucentSrc(t *testing.T) {
+	dst := color.RGBA{0, 0, 0, 255}
+	src := color.RGBA{200, 200, 200, 128} // ~50% alpha
+
+	got := blendCellColor(dst, src, SrcOver)
+	if got.R == 0 || got.R == src.R {
+		t.Errorf("blendCellColor with a translucent src = %v, want a value between dst and src", got)
+	}
+}
+
+// TestRenderCellsXORMasking checks that XOR shows a layer's color only
+// where exactly one of it and the prior layers is "on", per the covered
+// grid renderCells tracks.
+func TestRenderCellsXORMasking(t *testing.T) {
+	bg := color.RGBA{0, 0, 0, 255}
+	red := color.RGBA{255, 0, 0, 255}
+	blue := color.RGBA{0, 0, 255, 255}
+
+	// A 2x2 grid: layer 0 lights (0,0) and (1,0); layer 1 (XOR) lights
+	// (0,0) and (0,1). (0,0) is covered by both (XOR should fall back to
+	// bg); (1,0) only by layer 0 (unaffected, stays red); (0,1) only by
+	// layer 1 (shows blue via XOR).
+	layers := []layerGrid{
+		{color: red, on: [][]bool{{true, true}, {false, false}}},
+		{color: blue, on: [][]bool{{true, false}, {true, false}}},
+	}
+	blendModes := map[int]BlendMode{1: XOR}
+
+	cells := renderCells(layers, bg, blendModes, 2)
+
+	if cells[0][0] != bg {
+		t.Errorf("cell (0,0) = %v, want background %v (covered by both layers, XOR masks it)", cells[0][0], bg)
+	}
+	if cells[0][1] != red {
+		t.Errorf("cell (1,0) = %v, want %v (only layer 0 on)", cells[0][1], red)
+	}
+	if cells[1][0] != blue {
+		t.Errorf("cell (0,1) = %v, want %v (only layer 1 on, via XOR)", cells[1][0], blue)
+	}
+	if cells[1][1] != bg {
+		t.Errorf("cell (1,1) = %v, want background %v (neither layer on)", cells[1][1], bg)
+	}
+}
+
+// TestRenderCellsDefaultModeIsSrcOver checks that an unset blend mode
+// (the zero value) behaves as plain alpha-over, not XOR's masking.
+func TestRenderCellsDefaultModeIsSrcOver(t *testing.T) {
+	bg := color.RGBA{0, 0, 0, 255}
+	red := color.RGBA{255, 0, 0, 255}
+	blue := color.RGBA{0, 0, 255, 255}
+
+	layers := []layerGrid{
+		{color: red, on: [][]bool{{true}}},
+		{color: blue, on: [][]bool{{true}}},
+	}
+
+	cells := renderCells(layers, bg, nil, 1)
+
+	if cells[0][0] != blue {
+		t.Errorf("cell (0,0) = %v, want %v (later SrcOver layer wins outright)", cells[0][0], blue)
+	}
+}
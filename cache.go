@@ -0,0 +1,202 @@
+package goavatar
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Thumbnailer renders and memoizes avatar bytes for a given input and
+// output format, so repeated requests for the same avatar don't redo
+// the (cheap but non-free) hashing, pattern generation and encoding
+// work.
+type Thumbnailer interface {
+	RenderBytes(input string, format Format) ([]byte, error)
+}
+
+// sizedRenderer is an optional interface a Thumbnailer may implement to
+// let AvatarHandler honor a per-request ?size= override without
+// widening the public Thumbnailer contract. *cachingAvatarer implements
+// it; a caller's own Thumbnailer simply won't get ?size= support.
+type sizedRenderer interface {
+	renderSized(input string, format Format, size int) ([]byte, error)
+}
+
+// cachingAvatarer is a Thumbnailer backed by an in-memory LRU cache,
+// keyed by a content-addressed hash of (input, options, format).
+type cachingAvatarer struct {
+	cache    *lruCache
+	baseOpts []OptFunc
+}
+
+// NewCachingAvatarer returns a Thumbnailer that memoizes rendered bytes
+// for up to cacheSize distinct (input, options, format) combinations.
+// opts are the baseline rendering options applied to every render; a
+// per-request size override is available through AvatarHandler's
+// ?size= query parameter.
+func NewCachingAvatarer(cacheSize int, opts ...OptFunc) Thumbnailer {
+	return &cachingAvatarer{
+		cache:    newLRUCache(cacheSize),
+		baseOpts: opts,
+	}
+}
+
+// RenderBytes renders input in the given format, serving from cache when
+// the (input, options, format) combination has already been rendered.
+func (c *cachingAvatarer) RenderBytes(input string, format Format) ([]byte, error) {
+	return c.render(input, format, nil)
+}
+
+// renderSized renders input at a specific size, overriding any size set
+// in baseOpts. Used by AvatarHandler for ?size=.
+func (c *cachingAvatarer) renderSized(input string, format Format, size int) ([]byte, error) {
+	return c.render(input, format, []OptFunc{WithSize(size)})
+}
+
+func (c *cachingAvatarer) render(input string, format Format, extra []OptFunc) ([]byte, error) {
+	opts := make([]OptFunc, 0, len(c.baseOpts)+len(extra))
+	opts = append(opts, c.baseOpts...)
+	opts = append(opts, extra...)
+
+	key := cacheKeyFor(input, opts, format)
+	if cached, ok := c.cache.Get(key); ok {
+		return cached, nil
+	}
+
+	var buf bytes.Buffer
+	if err := MakeAndEncode(&buf, input, format, opts...); err != nil {
+		return nil, err
+	}
+
+	rendered := buf.Bytes()
+	c.cache.Add(key, rendered)
+	return rendered, nil
+}
+
+// cacheKeyFor derives a deterministic, content-addressed cache key from
+// the fully-resolved options rather than the OptFunc values themselves
+// (which aren't comparable), so two option sets that resolve to the
+// same field values share a cache entry. Option sets that differ in
+// fields yet render alike (e.g. a zero-valued WithLayerColor entry, which
+// falls back to the hash-derived color) get separate entries.
+func cacheKeyFor(input string, opts []OptFunc, format Format) string {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	h := sha256.New()
+	fmt.Fprintf(h, "%s|%d|%d|%v|%v|%d|%d|%d|%p|%s",
+		input, o.size, o.gridSize, o.bgColor, o.fgColors, o.layers, o.symmetry, format, o.hasher, blendModeKey(o.blendModes))
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+// blendModeKey renders o.blendModes as a deterministic string for
+// folding into cacheKeyFor's hashed key. Map iteration order isn't
+// deterministic, so the layer indices are sorted first - without that,
+// two options resolving to the same blend modes could still hash to
+// different keys depending on map iteration order.
+func blendModeKey(blendModes map[int]BlendMode) string {
+	layers := make([]int, 0, len(blendModes))
+	for li := range blendModes {
+		layers = append(layers, li)
+	}
+	sort.Ints(layers)
+
+	var b strings.Builder
+	for _, li := range layers {
+		fmt.Fprintf(&b, "%d:%d,", li, blendModes[li])
+	}
+	return b.String()
+}
+
+// formatsByExt maps the URL extensions AvatarHandler accepts to Format
+// values.
+var formatsByExt = map[string]Format{
+	"png":  FormatPNG,
+	"jpg":  FormatJPEG,
+	"jpeg": FormatJPEG,
+	"gif":  FormatGIF,
+	"bmp":  FormatBMP,
+	"svg":  FormatSVG,
+}
+
+// parseAvatarPath splits a request path of the form
+// "/avatar/{id}.{ext}" into the avatar id and its requested Format.
+func parseAvatarPath(p string) (id string, format Format, ok bool) {
+	p = strings.TrimPrefix(p, "/avatar/")
+	dot := strings.LastIndex(p, ".")
+	if dot <= 0 {
+		return "", 0, false
+	}
+
+	id, ext := p[:dot], p[dot+1:]
+	format, known := formatsByExt[ext]
+	if !known {
+		return "", 0, false
+	}
+
+	return id, format, true
+}
+
+// minAvatarSize and maxAvatarSize bound the ?size= query parameter
+// AvatarHandler accepts. WithSize already floors anything below 64, so
+// rejecting a too-small value here instead of silently upsizing it turns
+// a confusing 200 into a clear 400; the upper bound keeps an
+// unauthenticated caller from driving an arbitrarily large
+// size*size*4-byte allocation (e.g. ?size=1000000) through the handler.
+const (
+	minAvatarSize = 64
+	maxAvatarSize = 2048
+)
+
+// AvatarHandler adapts a Thumbnailer to an http.Handler serving
+// "/avatar/{id}.{png|jpg|gif|bmp|svg}?size=...", with an ETag derived
+// from a SHA-256 of the (deterministic) rendered bytes and a
+// Cache-Control header letting clients and CDNs cache avatars for a day.
+func AvatarHandler(t Thumbnailer) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, format, ok := parseAvatarPath(r.URL.Path)
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+
+		var (
+			body []byte
+			err  error
+		)
+
+		sizeParam := r.URL.Query().Get("size")
+		if sr, canSize := t.(sizedRenderer); canSize && sizeParam != "" {
+			size, convErr := strconv.Atoi(sizeParam)
+			if convErr != nil || size < minAvatarSize || size > maxAvatarSize {
+				http.Error(w, fmt.Sprintf("size must be an integer between %d and %d", minAvatarSize, maxAvatarSize), http.StatusBadRequest)
+				return
+			}
+			body, err = sr.renderSized(id, format, size)
+		} else {
+			body, err = t.RenderBytes(id, format)
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		etag := fmt.Sprintf(`"%x"`, sha256.Sum256(body))
+		w.Header().Set("Content-Type", format.ContentType())
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Cache-Control", "public, max-age=86400, immutable")
+
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		w.Write(body)
+	})
+}
@@ -0,0 +1,180 @@
+// Command goavatar-fix rewrites call sites from the deprecated
+// WithFgColor API to the layer-addressable WithLayerColor API, and
+// flags call sites that configure more layers (via WithLayers) than
+// they supply colors for.
+//
+// Usage:
+//
+//	goavatar-fix [-w] file.go [file.go ...]
+//
+// By default goavatar-fix prints the rewritten source of each file it
+// changes to stdout, and any warnings to stderr; pass -w to rewrite
+// files in place, like gofmt.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"strconv"
+)
+
+var write = flag.Bool("w", false, "write result to (rather than stdout)")
+
+func main() {
+	flag.Parse()
+	if flag.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "usage: goavatar-fix [-w] file.go ...")
+		os.Exit(2)
+	}
+
+	exit := 0
+	for _, path := range flag.Args() {
+		if err := fixFile(path); err != nil {
+			fmt.Fprintf(os.Stderr, "goavatar-fix: %s: %v\n", path, err)
+			exit = 1
+		}
+	}
+	os.Exit(exit)
+}
+
+// fixFile rewrites the deprecated WithFgColor calls in path and reports
+// (on stderr) any Make/MakeAndEncode/MakeAnimated call site whose
+// WithLayers count isn't matched by as many layer colors.
+func fixFile(path string) error {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, nil, parser.ParseComments)
+	if err != nil {
+		return err
+	}
+
+	changed := false
+
+	ast.Inspect(file, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok || !isMakeCall(call) {
+			return true
+		}
+		if fixOptFuncArgs(call) {
+			changed = true
+		}
+		checkLayerCoverage(fset, call)
+		return true
+	})
+
+	if !changed {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	if err := format.Node(&buf, fset, file); err != nil {
+		return err
+	}
+
+	if *write {
+		return os.WriteFile(path, buf.Bytes(), 0o644)
+	}
+	_, err = os.Stdout.Write(buf.Bytes())
+	return err
+}
+
+// isMakeCall reports whether call is one of the package entry points
+// that accepts ...OptFunc: Make, MakeAndEncode, MakeAnimated.
+func isMakeCall(call *ast.CallExpr) bool {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return false
+	}
+	switch sel.Sel.Name {
+	case "Make", "MakeAndEncode", "MakeAnimated":
+		return true
+	default:
+		return false
+	}
+}
+
+// fixOptFuncArgs rewrites any goavatar.WithFgColor(r, g, b, a) argument
+// of call into goavatar.WithLayerColor(0, r, g, b, a), since WithFgColor
+// has always just been shorthand for "layer 0". It reports whether it
+// changed anything.
+func fixOptFuncArgs(call *ast.CallExpr) bool {
+	changed := false
+	for _, arg := range call.Args {
+		inner, ok := arg.(*ast.CallExpr)
+		if !ok {
+			continue
+		}
+		sel, ok := inner.Fun.(*ast.SelectorExpr)
+		if !ok || sel.Sel.Name != "WithFgColor" {
+			continue
+		}
+
+		sel.Sel.Name = "WithLayerColor"
+		zero := &ast.BasicLit{Kind: token.INT, Value: "0"}
+		inner.Args = append([]ast.Expr{zero}, inner.Args...)
+		changed = true
+	}
+	return changed
+}
+
+// checkLayerCoverage warns on stderr when a call site requests more
+// layers (via WithLayers(n)) than it supplies colors for (via
+// WithFgColor/WithLayerColor), since those layers silently fall back to
+// a hash-derived color today.
+func checkLayerCoverage(fset *token.FileSet, call *ast.CallExpr) {
+	wantLayers := 0
+	haveColors := map[int]bool{}
+
+	for _, arg := range call.Args {
+		inner, ok := arg.(*ast.CallExpr)
+		if !ok {
+			continue
+		}
+		sel, ok := inner.Fun.(*ast.SelectorExpr)
+		if !ok {
+			continue
+		}
+
+		switch sel.Sel.Name {
+		case "WithLayers":
+			if n, ok := intLitArg(inner.Args, 0); ok {
+				wantLayers = n
+			}
+		case "WithFgColor":
+			haveColors[0] = true
+		case "WithLayerColor":
+			if idx, ok := intLitArg(inner.Args, 0); ok {
+				haveColors[idx] = true
+			}
+		}
+	}
+
+	if wantLayers == 0 || len(haveColors) >= wantLayers {
+		return
+	}
+
+	pos := fset.Position(call.Pos())
+	fmt.Fprintf(os.Stderr, "%s: WithLayers(%d) but only %d layer color(s) supplied; unspecified layers fall back to a hash-derived color\n",
+		pos, wantLayers, len(haveColors))
+}
+
+// intLitArg extracts an integer literal argument at index i, if present.
+func intLitArg(args []ast.Expr, i int) (int, bool) {
+	if i >= len(args) {
+		return 0, false
+	}
+	lit, ok := args[i].(*ast.BasicLit)
+	if !ok || lit.Kind != token.INT {
+		return 0, false
+	}
+	n, err := strconv.Atoi(lit.Value)
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bytes"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"strings"
+	"testing"
+)
+
+// parseCall parses src (a single statement containing one call
+// expression) and returns its outermost *ast.CallExpr along with the
+// fset needed to report positions.
+func parseCall(t *testing.T, src string) (*token.FileSet, *ast.File, *ast.CallExpr) {
+	t.Helper()
+
+	full := "package p\nfunc f() {\n" + src + "\n}\n"
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "test.go", full, 0)
+	if err != nil {
+		t.Fatalf("ParseFile() returned error: %v", err)
+	}
+
+	var call *ast.CallExpr
+	ast.Inspect(file, func(n ast.Node) bool {
+		if c, ok := n.(*ast.CallExpr); ok && call == nil {
+			call = c
+		}
+		return true
+	})
+	if call == nil {
+		t.Fatalf("no call expression found in %q", src)
+	}
+	return fset, file, call
+}
+
+func TestFixOptFuncArgsRewritesWithFgColor(t *testing.T) {
+	fset, file, call := parseCall(t, `goavatar.Make("x", goavatar.WithFgColor(1, 2, 3, 255))`)
+
+	if !fixOptFuncArgs(call) {
+		t.Fatal("fixOptFuncArgs() returned false, want true for a WithFgColor call")
+	}
+
+	var buf bytes.Buffer
+	if err := format.Node(&buf, fset, file); err != nil {
+		t.Fatalf("format.Node() returned error: %v", err)
+	}
+
+	got := buf.String()
+	if strings.Contains(got, "WithFgColor") {
+		t.Errorf("rewritten source still contains WithFgColor:\n%s", got)
+	}
+	if !strings.Contains(got, "WithLayerColor(0, 1, 2, 3, 255)") {
+		t.Errorf("rewritten source missing WithLayerColor(0, 1, 2, 3, 255):\n%s", got)
+	}
+}
+
+func TestFixOptFuncArgsLeavesOtherCallsAlone(t *testing.T) {
+	_, _, call := parseCall(t, `goavatar.Make("x", goavatar.WithLayerColor(1, 1, 2, 3, 255))`)
+
+	if fixOptFuncArgs(call) {
+		t.Error("fixOptFuncArgs() returned true for a call with no WithFgColor argument")
+	}
+}
+
+func TestCheckLayerCoverageWarnsOnMismatch(t *testing.T) {
+	fset, _, call := parseCall(t, `goavatar.Make("x", goavatar.WithLayers(3), goavatar.WithFgColor(1, 2, 3, 255))`)
+
+	stderr := captureStderr(t, func() {
+		checkLayerCoverage(fset, call)
+	})
+
+	if !strings.Contains(stderr, "WithLayers(3) but only 1 layer color(s) supplied") {
+		t.Errorf("stderr = %q, want a WithLayers/layer-color mismatch warning", stderr)
+	}
+}
+
+func TestCheckLayerCoverageSilentWhenSatisfied(t *testing.T) {
+	fset, _, call := parseCall(t, `goavatar.Make("x", goavatar.WithLayers(2), goavatar.WithLayerColor(0, 1, 2, 3, 255), goavatar.WithLayerColor(1, 4, 5, 6, 255))`)
+
+	stderr := captureStderr(t, func() {
+		checkLayerCoverage(fset, call)
+	})
+
+	if stderr != "" {
+		t.Errorf("stderr = %q, want no warning when every layer has a color", stderr)
+	}
+}
+
+// captureStderr redirects os.Stderr for the duration of fn and returns
+// whatever was written to it.
+func captureStderr(t *testing.T, fn func()) string {
+	t.Helper()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("os.Pipe() returned error: %v", err)
+	}
+
+	orig := os.Stderr
+	os.Stderr = w
+	defer func() { os.Stderr = orig }()
+
+	fn()
+
+	w.Close()
+	var buf bytes.Buffer
+	buf.ReadFrom(r)
+	return buf.String()
+}
@@ -0,0 +1,209 @@
+package goavatar
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"math"
+
+	"golang.org/x/image/bmp"
+)
+
+// Format identifies the wire encoding Encode/MakeAndEncode should produce.
+type Format int
+
+const (
+	// FormatPNG encodes a lossless raster PNG.
+	FormatPNG Format = iota
+	// FormatJPEG encodes a lossy raster JPEG.
+	FormatJPEG
+	// FormatGIF encodes a paletted GIF.
+	FormatGIF
+	// FormatBMP encodes an uncompressed BMP.
+	FormatBMP
+	// FormatSVG encodes a scalable vector SVG, one <rect> per grid cell.
+	FormatSVG
+)
+
+// ContentType returns the MIME type that should accompany bytes produced
+// for the given format, e.g. over HTTP.
+func (f Format) ContentType() string {
+	switch f {
+	case FormatPNG:
+		return "image/png"
+	case FormatJPEG:
+		return "image/jpeg"
+	case FormatGIF:
+		return "image/gif"
+	case FormatBMP:
+		return "image/bmp"
+	case FormatSVG:
+		return "image/svg+xml"
+	default:
+		return "application/octet-stream"
+	}
+}
+
+// Encode writes img to w in the given format. PNG, JPEG and BMP encode
+// img as-is; GIF quantizes it to a paletted image first. FormatSVG
+// always returns an error here, since a generic image.Image carries no
+// grid/layer information to vectorize; use
+// MakeAndEncode(w, input, FormatSVG, opts...) instead.
+func Encode(w io.Writer, img image.Image, format Format) error {
+	switch format {
+	case FormatPNG:
+		return png.Encode(w, img)
+	case FormatJPEG:
+		return jpeg.Encode(w, img, nil)
+	case FormatGIF:
+		return gif.Encode(w, img, nil)
+	case FormatBMP:
+		return bmp.Encode(w, img)
+	case FormatSVG:
+		return fmt.Errorf("goavatar: FormatSVG requires MakeAndEncode, not a rasterized image.Image")
+	default:
+		return fmt.Errorf("goavatar: unknown format %d", format)
+	}
+}
+
+// MakeAndEncode generates an avatar for input and writes it to w in the
+// requested format, giving callers a single call site for delivering
+// avatars over HTTP with the right content type. FormatSVG and FormatGIF
+// render directly from the grid (no rasterize-then-quantize pass); the
+// other formats render through Make.
+func MakeAndEncode(w io.Writer, input string, format Format, opts ...OptFunc) error {
+	switch format {
+	case FormatSVG:
+		return encodeSVG(w, input, opts...)
+	case FormatGIF:
+		return gif.Encode(w, makePaletted(input, opts...), nil)
+	default:
+		return Encode(w, Make(input, opts...), format)
+	}
+}
+
+// makePaletted renders the avatar directly as an *image.Paletted, built
+// from renderCells' resolved cell colors, avoiding a post-hoc
+// quantization pass over an *image.RGBA. The palette is built from the
+// distinct colors renderCells actually produced rather than one entry
+// per layer, since a blend mode can resolve a cell to a color that
+// matches none of the raw layer colors.
+func makePaletted(input string, opts ...OptFunc) *image.Paletted {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	cells := renderCells(computeLayers(input, o), o.bgColor, o.blendModes, o.gridSize)
+	pal := cellPalette(o.bgColor, cells)
+
+	img := image.NewPaletted(image.Rect(0, 0, o.size, o.size), pal)
+	draw.Draw(img, img.Bounds(), &image.Uniform{o.bgColor}, image.Point{}, draw.Src)
+
+	for y, row := range cells {
+		for x, c := range row {
+			idx := uint8(pal.Index(c))
+			drawPalettedPixel(img, x, y, idx, o.gridSize, o.size)
+		}
+	}
+
+	return img
+}
+
+// cellPalette collects bgColor plus every distinct color appearing in
+// cells, capped at 256 entries (the max a color.Palette/GIF can address)
+// - mirroring buildAnimationPalette's same dedup-and-cap approach for a
+// single frame's cells instead of a run of animation frames.
+func cellPalette(bgColor color.RGBA, cells [][]color.RGBA) color.Palette {
+	pal := color.Palette{bgColor}
+	seen := map[color.RGBA]bool{bgColor: true}
+
+	for _, row := range cells {
+		for _, c := range row {
+			if len(pal) >= 256 {
+				return pal
+			}
+			if !seen[c] {
+				seen[c] = true
+				pal = append(pal, c)
+			}
+		}
+	}
+
+	return pal
+}
+
+// drawPalettedPixel mirrors drawPixel's proportional-scaling math for a
+// paletted image, so GIF output lines up pixel-for-pixel with Make.
+func drawPalettedPixel(img *image.Paletted, gridX, gridY int, paletteIndex uint8, gridSize, imageSize int) {
+	startX := int(math.Round(float64(gridX) * float64(imageSize) / float64(gridSize)))
+	startY := int(math.Round(float64(gridY) * float64(imageSize) / float64(gridSize)))
+	endX := int(math.Round(float64(gridX+1) * float64(imageSize) / float64(gridSize)))
+	endY := int(math.Round(float64(gridY+1) * float64(imageSize) / float64(gridSize)))
+
+	if endX > img.Bounds().Dx() {
+		endX = img.Bounds().Dx()
+	}
+	if endY > img.Bounds().Dy() {
+		endY = img.Bounds().Dy()
+	}
+
+	for y := startY; y < endY; y++ {
+		for x := startX; x < endX; x++ {
+			img.SetColorIndex(x, y, paletteIndex)
+		}
+	}
+}
+
+// encodeSVG writes scalable vector output from renderCells' resolved
+// cell colors: one <rect> for the background plus one per cell whose
+// resolved color differs from it, so avatars stay crisp at any display
+// size instead of being rasterized. Rendering from renderCells (rather
+// than walking computeLayers' per-layer grids directly, as earlier
+// versions did) means a blend mode applied to one layer shows up in the
+// SVG exactly as it does in the PNG/GIF output of the same avatar.
+func encodeSVG(w io.Writer, input string, opts ...OptFunc) error {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	cells := renderCells(computeLayers(input, o), o.bgColor, o.blendModes, o.gridSize)
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`,
+		o.size, o.size, o.size, o.size)
+	fmt.Fprintf(&buf, `<rect width="%d" height="%d" fill="%s"/>`, o.size, o.size, hexColor(o.bgColor))
+
+	cell := float64(o.size) / float64(o.gridSize)
+	for y, row := range cells {
+		for x, c := range row {
+			if c == o.bgColor {
+				continue
+			}
+			startX := int(float64(x) * cell)
+			startY := int(float64(y) * cell)
+			endX := int(float64(x+1) * cell)
+			endY := int(float64(y+1) * cell)
+			fmt.Fprintf(&buf, `<rect x="%d" y="%d" width="%d" height="%d" fill="%s"/>`,
+				startX, startY, endX-startX, endY-startY, hexColor(c))
+		}
+	}
+
+	buf.WriteString(`</svg>`)
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// hexColor formats c as a CSS hex color, dropping alpha: every SVG rect is
+// written fully opaque, so a translucent background or cell color is not
+// preserved in SVG output.
+func hexColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
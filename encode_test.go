@@ -0,0 +1,68 @@
+package goavatar
+
+import (
+	"bytes"
+	"image"
+	"image/gif"
+	"testing"
+)
+
+func TestMakeAndEncodeFormats(t *testing.T) {
+	tests := []struct {
+		name   string
+		format Format
+	}{
+		{"PNG", FormatPNG},
+		{"JPEG", FormatJPEG},
+		{"GIF", FormatGIF},
+		{"BMP", FormatBMP},
+		{"SVG", FormatSVG},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := MakeAndEncode(&buf, "format-test", tt.format); err != nil {
+				t.Fatalf("MakeAndEncode(%s) returned error: %v", tt.name, err)
+			}
+			if buf.Len() == 0 {
+				t.Fatalf("MakeAndEncode(%s) wrote no bytes", tt.name)
+			}
+			if ct := tt.format.ContentType(); ct == "application/octet-stream" {
+				t.Errorf("ContentType() for %s fell back to the unknown-format default", tt.name)
+			}
+		})
+	}
+}
+
+func TestMakeAndEncodeGIFDecodes(t *testing.T) {
+	var buf bytes.Buffer
+	if err := MakeAndEncode(&buf, "gif-roundtrip", FormatGIF); err != nil {
+		t.Fatalf("MakeAndEncode(FormatGIF) returned error: %v", err)
+	}
+
+	img, err := gif.Decode(&buf)
+	if err != nil {
+		t.Fatalf("gif.Decode() returned error: %v", err)
+	}
+	if b := img.Bounds(); b.Dx() != 64 || b.Dy() != 64 {
+		t.Errorf("decoded GIF size = %dx%d, want 64x64", b.Dx(), b.Dy())
+	}
+}
+
+func TestEncodeRejectsSVGForRasterImage(t *testing.T) {
+	img := Make("raster-test")
+	var buf bytes.Buffer
+	if err := Encode(&buf, img, FormatSVG); err == nil {
+		t.Fatal("Encode(FormatSVG) with a rasterized image.Image returned nil error, want one")
+	}
+}
+
+func TestEncodeUnknownFormat(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 1, 1))
+	var buf bytes.Buffer
+	if err := Encode(&buf, img, Format(999)); err == nil {
+		t.Fatal("Encode() with an unknown format returned nil error, want one")
+	}
+}
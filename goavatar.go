@@ -11,11 +11,15 @@ import (
 
 // options contains the configuration for the avatar generator.
 type options struct {
-	size     int
-	gridSize int
-	bgColor  color.RGBA
-	fgColors []color.RGBA
-	layers   int
+	size       int
+	gridSize   int
+	bgColor    color.RGBA
+	fgColors   []color.RGBA
+	layers     int
+	animation  animOptions
+	hasher     func([]byte) []byte
+	symmetry   SymmetryMode
+	blendModes map[int]BlendMode // layer index -> blend mode; unset layers use the zero value, SrcOver
 }
 
 // OptFunc is a function that applies an option to the options struct.
@@ -50,6 +54,10 @@ func WithBgColor(r, g, b, a uint8) OptFunc {
 
 // WithFgColor sets the foreground color of the avatar.
 // It sets the first layer's color.
+//
+// Deprecated: use WithLayerColor(0, r, g, b, a) instead, which also
+// addresses layers beyond the first. cmd/goavatar-fix rewrites existing
+// call sites automatically.
 func WithFgColor(r, g, b, a uint8) OptFunc {
 	return func(o *options) {
 		o.fgColors = []color.RGBA{{r, g, b, a}}
@@ -77,17 +85,28 @@ func WithLayerColor(layerIndex int, r, g, b, a uint8) OptFunc {
 }
 
 // defaultOptions provides the default value to generate the avatar.
-func defaultOptions(hash string) options {
+// fgColors starts empty: computeLayers falls back to a hash-derived
+// color for any layer without an explicit WithFgColor/WithLayerColor,
+// so there is nothing to precompute here before opts are applied.
+func defaultOptions() options {
 	return options{
-		size:     64,                            // default size should be 64 to make sure images are perfect square
-		gridSize: 8,                             // minimum size for the grid for make shape complexity
-		bgColor:  color.RGBA{240, 240, 240, 255}, // light gray color
-		fgColors: []color.RGBA{{hash[0], hash[1], hash[2], 255}}, // use the first three hash bytes as the foreground color
-		layers:   1,
+		size:      64,                            // default size should be 64 to make sure images are perfect square
+		gridSize:  8,                             // minimum size for the grid for make shape complexity
+		bgColor:   color.RGBA{240, 240, 240, 255}, // light gray color
+		layers:    1,
+		animation: defaultAnimOptions(),
+		hasher:    MD5Hasher,
+		symmetry:  HorizontalMirror,
 	}
 }
 
 // generateHash generates the MD5 hash of the input string.
+//
+// This hex-string chaining is kept around for MakeAnimated's
+// HashChainMode, which evolves the *input seed* between frames rather
+// than the per-layer bit pattern; Make/computeLayers draw their pattern
+// bits from the pluggable o.hasher via bitReader instead (see
+// WithHasher/WithSymmetry).
 func generateHash(data string) string {
 	hash := md5.Sum([]byte(data))
 	return hex.EncodeToString(hash[:])
@@ -117,71 +136,81 @@ func drawPixel(img *image.RGBA, gridX, gridY int, c color.Color, gridSize, image
 	}
 }
 
-// Make generates an avatar image based on the input string and options.
-func Make(input string, opts ...OptFunc) image.Image {
-	// generate the hash of an input
-	hash := generateHash(input)
-	o := defaultOptions(hash)
-
-	for _, opt := range opts {
-		opt(&o)
-	}
-
-	// create a blank image
-	img := image.NewRGBA(image.Rect(0, 0, o.size, o.size))
+// layerGrid holds the resolved color and on/off pixel grid for a single
+// avatar layer, already mirrored. It is the shared unit of work between
+// Make and the alternate encoders (SVG, paletted and animated GIF) so they all
+// walk the exact same pattern.
+type layerGrid struct {
+	color color.RGBA
+	on    [][]bool // on[y][x]
+}
 
-	// Fill background
-	draw.Draw(img, img.Bounds(), &image.Uniform{o.bgColor}, image.Point{}, draw.Src)
+// computeLayers replays the hash-chain/layer loop and returns, for every
+// layer, the resolved color and the on/off grid (already expanded for
+// o.symmetry) that Make (and the other encoders) paint from.
+//
+// Each layer's seed is hashed with o.hasher; bits are then drawn from a
+// bitReader over the *raw* hash bytes rather than indexing into a hex
+// string, so the full entropy of the hash is available and grids larger
+// than the hash output (e.g. gridSize > 16 with MD5's 16 bytes) simply
+// cause the bitReader to rehash for more bits instead of wrapping.
+func computeLayers(input string, o options) []layerGrid {
+	seed := o.hasher([]byte(input))
 
-	currentHash := hash
-	isOdd := o.gridSize%2 != 0
+	layers := make([]layerGrid, 0, o.layers)
 
 	for l := 0; l < o.layers; l++ {
-		// derive hash for this layer
+		// derive the seed for this layer
 		if l > 0 {
-			currentHash = generateHash(currentHash)
+			seed = o.hasher(seed)
 		}
 
 		// determine color
 		var avatarColor color.RGBA
 		if l < len(o.fgColors) {
 			avatarColor = o.fgColors[l]
-			// Check if color is empty/zero? defaultOptions sets index 0.
-			// WithLayerColor might extend with zeros.
-			// If alpha is 0, should we generate? 
-			// User might purposefully set transparent? Unlikely for avatar foreground.
-			// Assuming if user sets it, they set it.
-			// But if we expanded with empty RGBA (0,0,0,0), it's invisible.
-			// If it is strictly 0,0,0,0, maybe fallback to hash? 
-			// Let's assume user provides valid colors if they use WithLayerColor.
-			// But for "unspecified" layers where user requested 3 layers but provided 1 color:
+			// WithLayerColor may have expanded the slice with zero
+			// values for layers the caller didn't specify; an
+			// unspecified layer falls back to a hash-derived color,
+			// same as a layer beyond len(o.fgColors) entirely.
 			if avatarColor == (color.RGBA{}) {
-				avatarColor = color.RGBA{currentHash[0], currentHash[1], currentHash[2], 255}
+				avatarColor = color.RGBA{seed[0], seed[1], seed[2], 255}
 			}
 		} else {
-			avatarColor = color.RGBA{currentHash[0], currentHash[1], currentHash[2], 255}
+			avatarColor = color.RGBA{seed[0], seed[1], seed[2], 255}
 		}
 
-		// generate the pixel pattern
-		// loop over each pixel in the grid
-		for y := 0; y < o.gridSize; y++ {
-			for x := 0; x < o.gridSize/2; x++ {
-				// use bitwise operation to determine if a pixel should be colored
-				pixelOn := (currentHash[y]>>(x%8))&1 == 1
-
-				if pixelOn {
-					drawPixel(img, x, y, avatarColor, o.gridSize, o.size)
-					drawPixel(img, o.gridSize-1-x, y, avatarColor, o.gridSize, o.size) // mirror the pixel
-				}
-			}
-			// Draw the center column if gridSize is odd
-			if isOdd {
-				mid := o.gridSize / 2
-				pixelOn := (currentHash[y]>>(mid%8))&1 == 1
-				if pixelOn {
-					drawPixel(img, mid, y, avatarColor, o.gridSize, o.size)
-				}
-			}
+		br := newBitReader(o.hasher, seed)
+		on := buildGrid(br, o.gridSize, o.symmetry)
+
+		layers = append(layers, layerGrid{color: avatarColor, on: on})
+	}
+
+	return layers
+}
+
+// Make generates an avatar image based on the input string and options.
+func Make(input string, opts ...OptFunc) image.Image {
+	o := defaultOptions()
+
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	// create a blank image
+	img := image.NewRGBA(image.Rect(0, 0, o.size, o.size))
+
+	// Fill background
+	draw.Draw(img, img.Bounds(), &image.Uniform{o.bgColor}, image.Point{}, draw.Src)
+
+	// renderCells resolves every grid cell's final color - including
+	// blend-mode compositing and XOR's covered-cell masking - once, so
+	// makePaletted and encodeSVG paint from the exact same cells instead
+	// of re-deriving (and potentially diverging from) this math.
+	cells := renderCells(computeLayers(input, o), o.bgColor, o.blendModes, o.gridSize)
+	for y, row := range cells {
+		for x, c := range row {
+			drawPixel(img, x, y, c, o.gridSize, o.size)
 		}
 	}
 
@@ -5,55 +5,57 @@ import (
 	"testing"
 )
 
-// expectedTopLeftPixel computes what color should appear at (0,0) by replaying the default options
-// and then using the same raw hash logic as in Make: for x=0, y=0, it tests if (hash[0] & 1) == 1.
-//
-// NOTE: generateHash returns a hex‑encoded string, so here we use its first character’s ASCII code.
+// expectedTopLeftPixel computes what color should appear at (0,0) by
+// replaying the default options and then using the same bitReader logic
+// as computeLayers: for the default HorizontalMirror symmetry, the cell
+// at x=0,y=0 is the very first bit the bitReader draws, i.e. the
+// least-significant bit of the first byte of hasher(seed).
 func expectedTopLeftPixel(input string, opts []OptFunc) (col color.Color) {
-	// generate the hash of the input
-	hash := generateHash(input)
-	// get the default configuration; which sets fgColor to {hash[0], hash[1], hash[2], 255}
-	conf := defaultOptions(hash)
+	// get the default configuration; fgColors starts empty so unspecified
+	// layers fall back to a hash-derived color
+	conf := defaultOptions()
 	// apply all option functions to the default configuration
 	for _, opt := range opts {
 		opt(&conf)
 	}
-	
+
 	// Determine the final color at (0,0)
 	// It's cumulative. Background first.
 	// Then layer 0. If pixelOn, draw layer 0 color.
 	// Then layer 1. If pixelOn, draw layer 1 color.
 	// ...
 	// Since we overwrite, the LAST active layer wins.
-	
+
 	finalColor := conf.bgColor
 
-	currentHash := hash
-	
+	seed := conf.hasher([]byte(input))
+
 	for l := 0; l < conf.layers; l++ {
 		if l > 0 {
-			currentHash = generateHash(currentHash)
+			seed = conf.hasher(seed)
 		}
-		
-		// For the top‐left cell (x=0,y=0), the decision is based on the least‐significant bit of the raw hash character.
-		// Using the raw ASCII value of hash[0] as in the current implementation.
-		pixelOn := (currentHash[0] & 1) == 1
-		
-		if pixelOn {
-			// determine color for this layer
-			var layerColor color.RGBA
-			if l < len(conf.fgColors) {
-				layerColor = conf.fgColors[l]
-				if layerColor == (color.RGBA{}) {
-					layerColor = color.RGBA{currentHash[0], currentHash[1], currentHash[2], 255}
-				}
-			} else {
-				layerColor = color.RGBA{currentHash[0], currentHash[1], currentHash[2], 255}
+
+		// determine color for this layer
+		var layerColor color.RGBA
+		if l < len(conf.fgColors) {
+			layerColor = conf.fgColors[l]
+			if layerColor == (color.RGBA{}) {
+				layerColor = color.RGBA{seed[0], seed[1], seed[2], 255}
 			}
+		} else {
+			layerColor = color.RGBA{seed[0], seed[1], seed[2], 255}
+		}
+
+		// bitReader buffers hasher(seed) and reads the least-significant
+		// bit of byte 0 first.
+		buf := conf.hasher(seed)
+		pixelOn := buf[0]&1 == 1
+
+		if pixelOn {
 			finalColor = layerColor
 		}
 	}
-	
+
 	return finalColor
 }
 
@@ -0,0 +1,74 @@
+package goavatar
+
+import (
+	"container/list"
+	"sync"
+)
+
+// lruCache is a small goroutine-safe least-recently-used byte-slice
+// cache. It exists so NewCachingAvatarer doesn't have to pull in an
+// external dependency (e.g. hashicorp/golang-lru) for something this
+// simple: a bounded map plus a doubly linked list for recency order.
+type lruCache struct {
+	mu       sync.Mutex
+	capacity int
+	ll       *list.List
+	items    map[string]*list.Element
+}
+
+// lruEntry is the value stored in ll; it carries its own key so Add can
+// find and evict the map entry for the least-recently-used element.
+type lruEntry struct {
+	key   string
+	value []byte
+}
+
+// newLRUCache creates a cache holding at most capacity entries.
+func newLRUCache(capacity int) *lruCache {
+	if capacity <= 0 {
+		capacity = 1
+	}
+	return &lruCache{
+		capacity: capacity,
+		ll:       list.New(),
+		items:    make(map[string]*list.Element),
+	}
+}
+
+// Get returns the cached value for key, if present, and marks it
+// most-recently-used.
+func (c *lruCache) Get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	c.ll.MoveToFront(el)
+	return el.Value.(*lruEntry).value, true
+}
+
+// Add inserts or updates key, evicting the least-recently-used entry if
+// the cache is over capacity.
+func (c *lruCache) Add(key string, value []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		c.ll.MoveToFront(el)
+		el.Value.(*lruEntry).value = value
+		return
+	}
+
+	el := c.ll.PushFront(&lruEntry{key: key, value: value})
+	c.items[key] = el
+
+	if c.ll.Len() > c.capacity {
+		oldest := c.ll.Back()
+		if oldest != nil {
+			c.ll.Remove(oldest)
+			delete(c.items, oldest.Value.(*lruEntry).key)
+		}
+	}
+}
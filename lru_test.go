@@ -0,0 +1,58 @@
+package goavatar
+
+import "testing"
+
+func TestLRUCacheEvictsOldest(t *testing.T) {
+	c := newLRUCache(2)
+
+	c.Add("a", []byte("1"))
+	c.Add("b", []byte("2"))
+	c.Add("c", []byte("3")) // over capacity; "a" is least-recently-used
+
+	if _, ok := c.Get("a"); ok {
+		t.Error(`Get("a") found a value, want it evicted`)
+	}
+	if v, ok := c.Get("b"); !ok || string(v) != "2" {
+		t.Errorf(`Get("b") = %q, %v, want "2", true`, v, ok)
+	}
+	if v, ok := c.Get("c"); !ok || string(v) != "3" {
+		t.Errorf(`Get("c") = %q, %v, want "3", true`, v, ok)
+	}
+}
+
+func TestLRUCacheGetRefreshesRecency(t *testing.T) {
+	c := newLRUCache(2)
+
+	c.Add("a", []byte("1"))
+	c.Add("b", []byte("2"))
+	c.Get("a")               // "a" is now most-recently-used; "b" is oldest
+	c.Add("c", []byte("3")) // over capacity; "b" should be evicted, not "a"
+
+	if _, ok := c.Get("b"); ok {
+		t.Error(`Get("b") found a value, want it evicted after "a" was refreshed`)
+	}
+	if v, ok := c.Get("a"); !ok || string(v) != "1" {
+		t.Errorf(`Get("a") = %q, %v, want "1", true`, v, ok)
+	}
+}
+
+func TestLRUCacheAddUpdatesExistingKey(t *testing.T) {
+	c := newLRUCache(2)
+
+	c.Add("a", []byte("1"))
+	c.Add("a", []byte("updated"))
+
+	if v, ok := c.Get("a"); !ok || string(v) != "updated" {
+		t.Errorf(`Get("a") = %q, %v, want "updated", true`, v, ok)
+	}
+	if c.ll.Len() != 1 {
+		t.Errorf("ll.Len() = %d after updating an existing key, want 1", c.ll.Len())
+	}
+}
+
+func TestNewLRUCacheRejectsNonPositiveCapacity(t *testing.T) {
+	c := newLRUCache(0)
+	if c.capacity != 1 {
+		t.Errorf("newLRUCache(0).capacity = %d, want 1", c.capacity)
+	}
+}
@@ -0,0 +1,179 @@
+package goavatar
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+)
+
+// SymmetryMode selects how the bits drawn from the hash are mirrored (or
+// not) across the grid to build a pattern.
+type SymmetryMode int
+
+const (
+	// HorizontalMirror draws the left half of each row and mirrors it
+	// to the right half. This is the original/default pattern.
+	HorizontalMirror SymmetryMode = iota
+	// VerticalMirror draws the top half of each column and mirrors it
+	// to the bottom half.
+	VerticalMirror
+	// QuadrantMirror draws the top-left quadrant and mirrors it into
+	// all four quadrants (four-fold symmetry, as in classic Identicons).
+	QuadrantMirror
+	// Rotational90 draws the top-left quadrant and rotates it 90, 180
+	// and 270 degrees around the grid's center to fill the rest.
+	Rotational90
+	// None draws every cell independently from the hash, with no
+	// mirroring at all.
+	None
+)
+
+// MD5Hasher, SHA1Hasher and SHA256Hasher are the built-in WithHasher
+// options. MD5Hasher is the default: it's the smallest/cheapest and
+// plenty for the 8x8 default grid; SHA1Hasher and SHA256Hasher give a
+// larger entropy budget for bigger grids or stricter collision
+// resistance, at the cost of a slightly slower hash.
+var (
+	MD5Hasher    = md5HasherFunc
+	SHA1Hasher   = sha1HasherFunc
+	SHA256Hasher = sha256HasherFunc
+)
+
+func md5HasherFunc(data []byte) []byte {
+	sum := md5.Sum(data)
+	return sum[:]
+}
+
+func sha1HasherFunc(data []byte) []byte {
+	sum := sha1.Sum(data)
+	return sum[:]
+}
+
+func sha256HasherFunc(data []byte) []byte {
+	sum := sha256.Sum256(data)
+	return sum[:]
+}
+
+// WithHasher overrides the hash function used to derive both layer
+// colors and the grid pattern. The function must be deterministic (same
+// input, same output) since that's what makes avatars reproducible.
+func WithHasher(h func([]byte) []byte) OptFunc {
+	return func(o *options) {
+		if h != nil {
+			o.hasher = h
+		}
+	}
+}
+
+// WithSymmetry selects how bits drawn from the hash are mirrored across
+// the grid; see SymmetryMode.
+func WithSymmetry(mode SymmetryMode) OptFunc {
+	return func(o *options) {
+		o.symmetry = mode
+	}
+}
+
+// bitReader draws individual bits out of a hash's raw bytes,
+// least-significant-bit-first within each byte, to
+// match the original pixelOn := (hash[y]>>(x%8))&1 convention. When the
+// buffer is exhausted it is rehashed in place for a fresh set of bits,
+// so a grid larger than the hash's native output (e.g. gridSize > 16
+// with MD5's 16 bytes, which exhausts after only 128 bits) never wraps
+// around and reuses the same bits twice; it simply spends another hash
+// call for more entropy.
+//
+// Entropy budget: HorizontalMirror/VerticalMirror consume
+// gridSize*gridSize/2 bits per layer (the mirrored half is free);
+// QuadrantMirror and Rotational90 consume roughly gridSize*gridSize/4;
+// None consumes the full gridSize*gridSize. MD5 supplies 128 bits per
+// hash call, SHA-1 160, SHA-256 256 — past that the bitReader simply
+// rehashes for more.
+type bitReader struct {
+	hasher func([]byte) []byte
+	buf    []byte
+	pos    int // next bit index into buf, LSB-first per byte
+}
+
+// newBitReader seeds a bitReader from seed; seed is hashed again for the
+// initial bit buffer so that color bytes (seed[0:3]) and pattern bits
+// never come from literally the same leading bytes.
+func newBitReader(hasher func([]byte) []byte, seed []byte) *bitReader {
+	return &bitReader{hasher: hasher, buf: hasher(seed)}
+}
+
+// Bit returns the next pseudo-random bit, rehashing for more entropy
+// once the current buffer is exhausted.
+func (r *bitReader) Bit() bool {
+	if r.pos >= len(r.buf)*8 {
+		r.buf = r.hasher(r.buf)
+		r.pos = 0
+	}
+	byteIdx := r.pos / 8
+	bitIdx := uint(r.pos % 8)
+	r.pos++
+	return (r.buf[byteIdx]>>bitIdx)&1 == 1
+}
+
+// buildGrid draws a gridSize x gridSize on/off pattern from br,
+// expanding it according to symmetry.
+func buildGrid(br *bitReader, gridSize int, symmetry SymmetryMode) [][]bool {
+	on := make([][]bool, gridSize)
+	for y := range on {
+		on[y] = make([]bool, gridSize)
+	}
+
+	switch symmetry {
+	case VerticalMirror:
+		half := (gridSize + 1) / 2
+		for y := 0; y < half; y++ {
+			for x := 0; x < gridSize; x++ {
+				bit := br.Bit()
+				on[y][x] = bit
+				on[gridSize-1-y][x] = bit
+			}
+		}
+	case QuadrantMirror:
+		half := (gridSize + 1) / 2
+		for y := 0; y < half; y++ {
+			for x := 0; x < half; x++ {
+				bit := br.Bit()
+				on[y][x] = bit
+				on[y][gridSize-1-x] = bit
+				on[gridSize-1-y][x] = bit
+				on[gridSize-1-y][gridSize-1-x] = bit
+			}
+		}
+	case Rotational90:
+		half := (gridSize + 1) / 2
+		for y := 0; y < half; y++ {
+			for x := 0; x < half; x++ {
+				bit := br.Bit()
+				rx, ry := x, y
+				for turn := 0; turn < 4; turn++ {
+					on[ry][rx] = bit
+					rx, ry = gridSize-1-ry, rx
+				}
+			}
+		}
+	case None:
+		for y := 0; y < gridSize; y++ {
+			for x := 0; x < gridSize; x++ {
+				on[y][x] = br.Bit()
+			}
+		}
+	default: // HorizontalMirror
+		isOdd := gridSize%2 != 0
+		for y := 0; y < gridSize; y++ {
+			for x := 0; x < gridSize/2; x++ {
+				bit := br.Bit()
+				on[y][x] = bit
+				on[y][gridSize-1-x] = bit
+			}
+			if isOdd {
+				on[y][gridSize/2] = br.Bit()
+			}
+		}
+	}
+
+	return on
+}